@@ -33,7 +33,10 @@ WMS, KML    Web Clients, Google Earth  TileMapService
 */
 package Geodetic
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 type GlobalGeodetic struct {
 	tileSize float64
@@ -46,15 +49,25 @@ func NewGlobalGeodetic(tileSize int64) *GlobalGeodetic {
 }
 
 //Converts lat/lon to pixel coordinates in given zoom of the EPSG:4326 pyramid
-func  (this *GlobalGeodetic)  LatLonToPixels(lat float64, lon float64, zoom int64) (float64, float64) {
+func (this *GlobalGeodetic) LatLonToPixels(lat float64, lon float64, zoom int64) (float64, float64) {
 
-	res := 180 / 256.0 / math.Pow(2, float64(zoom))
-	px := (180 + lat) / res
-	py := (90 + lon) / res
+	res := this.Resolution(zoom)
+	px := (180 + lon) / res
+	py := (90 + lat) / res
 	return px, py
 
 }
 
+//Converts pixel coordinates in given zoom of the EPSG:4326 pyramid to lat/lon
+func (this *GlobalGeodetic) PixelsToLatLon(px float64, py float64, zoom int64) (float64, float64) {
+
+	res := this.Resolution(zoom)
+	lon := px*res - 180
+	lat := py*res - 90
+	return lat, lon
+
+}
+
 //Returns coordinates of the tile covering region in pixel coordinates
 func (this *GlobalGeodetic) PixelsToTile(px float64, py float64) (int64, int64) {
 	tx := int64(math.Ceil(px/this.tileSize) - 1)
@@ -62,12 +75,54 @@ func (this *GlobalGeodetic) PixelsToTile(px float64, py float64) (int64, int64)
 	return tx, ty
 }
 
+//Resolution (degrees/pixel) for given zoom level (measured at Equator)
 func (this *GlobalGeodetic) Resolution(zoom int64) float64 {
-	return 180 / 256.0 / math.Pow(2, float64(zoom))
+	return 180 / this.tileSize / math.Pow(2, float64(zoom))
 }
 
-//Returns bounds of the given tile
+//Returns bounds of the given tile in EPSG:4326 coordinates
 func (this *GlobalGeodetic) TileBounds(tx float64, ty float64, zoom int64) (float64, float64, float64, float64) {
-	res := 180 / 256.0 / math.Pow(2, float64(zoom))
-	return tx*256*res - 180, ty*256*res - 90, (tx+1)*256*res - 180, (ty+1)*256*res - 90
+	res := this.Resolution(zoom)
+	minx := tx*this.tileSize*res - 180
+	miny := ty*this.tileSize*res - 90
+	maxx := (tx+1)*this.tileSize*res - 180
+	maxy := (ty+1)*this.tileSize*res - 90
+	return minx, miny, maxx, maxy
+}
+
+//Returns bounds of the given tile in latitude/longitude, i.e. (minLat, minLon, maxLat, maxLon)
+func (this *GlobalGeodetic) TileLatLonBounds(tx float64, ty float64, zoom int64) (float64, float64, float64, float64) {
+	minx, miny, maxx, maxy := this.TileBounds(tx, ty, zoom)
+	return miny, minx, maxy, maxx
+}
+
+//KMLSuperOverlay emits a <Region>/<GroundOverlay> snippet for the given tile,
+//the primary use case of the EPSG:4326 profile per the docblock above.
+func (this *GlobalGeodetic) KMLSuperOverlay(tx int64, ty int64, zoom int64) string {
+	minLat, minLon, maxLat, maxLon := this.TileLatLonBounds(float64(tx), float64(ty), zoom)
+
+	return fmt.Sprintf(`<Region>
+  <LatLonAltBox>
+    <north>%f</north>
+    <south>%f</south>
+    <east>%f</east>
+    <west>%f</west>
+  </LatLonAltBox>
+  <Lod>
+    <minLodPixels>%d</minLodPixels>
+    <maxLodPixels>-1</maxLodPixels>
+  </Lod>
+</Region>
+<GroundOverlay>
+  <drawOrder>%d</drawOrder>
+  <Icon>
+    <href>%d/%d/%d.png</href>
+  </Icon>
+  <LatLonBox>
+    <north>%f</north>
+    <south>%f</south>
+    <east>%f</east>
+    <west>%f</west>
+  </LatLonBox>
+</GroundOverlay>`, maxLat, minLat, maxLon, minLon, int64(this.tileSize)/2, zoom, zoom, tx, ty, maxLat, minLat, maxLon, minLon)
 }
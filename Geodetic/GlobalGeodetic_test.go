@@ -1,9 +1,9 @@
 package Geodetic
 
 import (
-	"testing"
 	"math"
-
+	"strings"
+	"testing"
 )
 
 func floatEquals(a, b float64, eps float64) bool {
@@ -14,28 +14,89 @@ func floatEquals(a, b float64, eps float64) bool {
 }
 
 func TestLatLonToPixels(t *testing.T) {
-	px, py := LatLonToPixels(51.5287718, -0.2416819, 2)
+	geodetic := NewGlobalGeodetic(256)
+	px, py := geodetic.LatLonToPixels(51.5287718, -0.2416819, 2)
 
-	if !floatEquals(px, 1317.141, 0.001) {
-		t.Errorf("expected 1317.141 as px")
+	if !floatEquals(px, 1022.625, 0.001) {
+		t.Errorf("expected 1022.625 as px but was %g", px)
 	}
-	if !floatEquals(py, 510.625, 0.001) {
-		t.Errorf("expected 510.625 as py ")
+	if !floatEquals(py, 805.141, 0.001) {
+		t.Errorf("expected 805.141 as py but was %g", py)
+	}
+}
+
+func TestPixelsToLatLonRoundTrip(t *testing.T) {
+	geodetic := NewGlobalGeodetic(256)
+	px, py := geodetic.LatLonToPixels(51.5287718, -0.2416819, 2)
+	lat, lon := geodetic.PixelsToLatLon(px, py, 2)
+
+	if !floatEquals(lat, 51.5287718, 0.001) {
+		t.Errorf("expected 51.5287718 as lat but was %g", lat)
+	}
+	if !floatEquals(lon, -0.2416819, 0.001) {
+		t.Errorf("expected -0.2416819 as lon but was %g", lon)
 	}
 }
 
 func TestPixelsToTile(t *testing.T) {
-	tx, ty := PixelsToTile(1317.141, 510.625, 256)
+	geodetic := NewGlobalGeodetic(256)
+	tx, ty := geodetic.PixelsToTile(1022.625, 805.141)
+
+	if tx != 3 {
+		t.Errorf("expected 3 as tx but was %d", tx)
+	}
+	if ty != 3 {
+		t.Errorf("expected 3 as ty but was %d", ty)
+	}
+}
+
+func TestTileLatLonBounds(t *testing.T) {
+	geodetic := NewGlobalGeodetic(256)
+	minLat, minLon, maxLat, maxLon := geodetic.TileLatLonBounds(3, 3, 2)
 
-	if tx != 5 {
-		t.Errorf("expected 5 as tx")
+	if !floatEquals(minLat, 45, 0.0001) {
+		t.Errorf("expected minLat 45 but was %g", minLat)
+	}
+	if !floatEquals(minLon, -45, 0.0001) {
+		t.Errorf("expected minLon -45 but was %g", minLon)
+	}
+	if !floatEquals(maxLat, 90, 0.0001) {
+		t.Errorf("expected maxLat 90 but was %g", maxLat)
+	}
+	if !floatEquals(maxLon, 0, 0.0001) {
+		t.Errorf("expected maxLon 0 but was %g", maxLon)
+	}
+}
+
+func TestKMLSuperOverlay(t *testing.T) {
+	geodetic := NewGlobalGeodetic(256)
+	kml := geodetic.KMLSuperOverlay(3, 3, 2)
+
+	if !strings.Contains(kml, "<href>2/3/3.png</href>") {
+		t.Errorf("expected href 2/3/3.png, got:\n%s", kml)
+	}
+	if !strings.Contains(kml, "<drawOrder>2</drawOrder>") {
+		t.Errorf("expected drawOrder 2, got:\n%s", kml)
+	}
+	if !strings.Contains(kml, "<minLodPixels>128</minLodPixels>") {
+		t.Errorf("expected minLodPixels 128, got:\n%s", kml)
+	}
+	if !strings.Contains(kml, "<north>90.000000</north>") {
+		t.Errorf("expected north 90.000000, got:\n%s", kml)
+	}
+	if !strings.Contains(kml, "<south>45.000000</south>") {
+		t.Errorf("expected south 45.000000, got:\n%s", kml)
+	}
+	if !strings.Contains(kml, "<east>0.000000</east>") {
+		t.Errorf("expected east 0.000000, got:\n%s", kml)
 	}
-	if ty != 1 {
-		t.Errorf("expected 1 as ty")
+	if !strings.Contains(kml, "<west>-45.000000</west>") {
+		t.Errorf("expected west -45.000000, got:\n%s", kml)
 	}
 }
 
 func TestResolution(t *testing.T) {
+	geodetic := NewGlobalGeodetic(256)
 	expected_resolutions := [21]float64{
 		0.703125,
 		0.3515625,
@@ -62,8 +123,8 @@ func TestResolution(t *testing.T) {
 
 	for i := 0; i < 21; i++ {
 
-		resolution := Resolution(int64(i))
-		if !floatEquals(resolution, expected_resolutions[i],  0.0001) {
+		resolution := geodetic.Resolution(int64(i))
+		if !floatEquals(resolution, expected_resolutions[i], 0.0001) {
 			t.Errorf("expected resolution %g at zoom %d but was %g", expected_resolutions[i], i, resolution)
 		}
 	}
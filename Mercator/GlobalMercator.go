@@ -96,7 +96,10 @@
 */
 package Mercator
 
-import "math"
+import (
+	"math"
+	"strconv"
+)
 
 type GlobalMercator struct {
 	tileSize          float64
@@ -196,16 +199,117 @@ func (this *GlobalMercator) Resolution(zoom int64) float64 {
 }
 
 //Maximal scaledown zoom of the pyramid closest to the pixelSize.
-func (this *GlobalMercator) ZoomForPixelSize(pixelSize float64 ) int{
-	for i :=0;i<30;i++{
-		if pixelSize > this.Resolution(i){
-			if i!=0{
+func (this *GlobalMercator) ZoomForPixelSize(pixelSize float64) int {
+	for i := int64(0); i < 30; i++ {
+		if pixelSize > this.Resolution(i) {
+			if i != 0 {
+				return int(i - 1)
+			} else {
 				return 0
-			}else{
-				return i-1
 			}
 		}
 	}
+	return 29
 }
 
+//LOD is a single level-of-detail record, mirroring the ArcGIS TileInfo LOD table.
+type LOD struct {
+	Level      int64
+	Resolution float64
+	Scale      float64
+}
+
+//ScaleForZoom converts Resolution(zoom) (meters/pixel) to a map scale
+//denominator for a display of the given dpi, following the ArcGIS convention
+//of 0.0254 meters per inch.
+func (this *GlobalMercator) ScaleForZoom(zoom int64, dpi float64) float64 {
+	return (this.Resolution(zoom) * dpi) / 0.0254
+}
+
+//ZoomForScale converts scale to a resolution for a display of the given dpi
+//and returns ZoomForPixelSize of it: the deepest zoom level whose
+//ScaleForZoom is not smaller than scale, which is not always the closest one.
+func (this *GlobalMercator) ZoomForScale(scale float64, dpi float64) int {
+	targetResolution := (scale * 0.0254) / dpi
+	return this.ZoomForPixelSize(targetResolution)
+}
+
+//LODs builds the ArcGIS-style LOD table for zoom levels minZoom..maxZoom at
+//the given dpi, ready to emit as a tile-info manifest for ArcGIS/OpenLayers clients.
+func (this *GlobalMercator) LODs(minZoom int64, maxZoom int64, dpi float64) []LOD {
+	lods := make([]LOD, 0, maxZoom-minZoom+1)
+	for zoom := minZoom; zoom <= maxZoom; zoom++ {
+		lods = append(lods, LOD{
+			Level:      zoom,
+			Resolution: this.Resolution(zoom),
+			Scale:      this.ScaleForZoom(zoom, dpi),
+		})
+	}
+	return lods
+}
+
+//Converts TMS tile coordinates to Google Tile coordinates (flips Y, origin top-left)
+func (this *GlobalMercator) GoogleTile(tx int64, ty int64, zoom int64) (int64, int64) {
+	gx := tx
+	gy := (int64(1)<<uint(zoom) - 1) - ty
+	return gx, gy
+}
+
+//Converts Google Tile coordinates to TMS tile coordinates (flips Y, origin bottom-left)
+func (this *GlobalMercator) GoogleTileToTile(gx int64, gy int64, zoom int64) (int64, int64) {
+	tx := gx
+	ty := (int64(1)<<uint(zoom) - 1) - gy
+	return tx, ty
+}
 
+//Returns bounds of the given tile (Google XYZ notation) in EPSG:900913 coordinates
+func (this *GlobalMercator) GoogleTileBounds(gx int64, gy int64, zoom int64) (float64, float64, float64, float64) {
+	tx, ty := this.GoogleTileToTile(gx, gy, zoom)
+	return this.TileBounds(float64(tx), float64(ty), zoom)
+}
+
+//Returns bounds of the given tile (Google XYZ notation) in latitude/longitude using WGS84 datum
+func (this *GlobalMercator) GoogleTileLatLonBounds(gx int64, gy int64, zoom int64) (float64, float64, float64, float64) {
+	tx, ty := this.GoogleTileToTile(gx, gy, zoom)
+	return this.TileLatLonBounds(float64(tx), float64(ty), zoom)
+}
+
+//Converts TMS tile coordinates to a Microsoft QuadTree key, as described at
+//http://msdn2.microsoft.com/en-us/library/bb259689.aspx
+func (this *GlobalMercator) QuadTree(tx int64, ty int64, zoom int64) string {
+	quadKey := ""
+	_, gy := this.GoogleTile(tx, ty, zoom)
+	for i := zoom; i > 0; i-- {
+		digit := int64(0)
+		mask := int64(1) << uint(i-1)
+		if tx&mask != 0 {
+			digit += 1
+		}
+		if gy&mask != 0 {
+			digit += 2
+		}
+		quadKey += strconv.FormatInt(digit, 10)
+	}
+	return quadKey
+}
+
+//Converts a Microsoft QuadTree key back to TMS tile coordinates and zoom level
+func (this *GlobalMercator) QuadTreeToTile(quadkey string) (int64, int64, int64) {
+	tx := int64(0)
+	gy := int64(0)
+	zoom := int64(len(quadkey))
+	for i := zoom; i > 0; i-- {
+		mask := int64(1) << uint(i-1)
+		switch quadkey[zoom-i] {
+		case '1':
+			tx |= mask
+		case '2':
+			gy |= mask
+		case '3':
+			tx |= mask
+			gy |= mask
+		}
+	}
+	_, ty := this.GoogleTileToTile(tx, gy, zoom)
+	return tx, ty, zoom
+}
@@ -0,0 +1,134 @@
+/*
+	WGS84 Ellipsoidal Mercator Profile
+	----------------------------------
+
+	GlobalMercator (GlobalMercator.go) uses the spherical form of the projection
+	(a=b=6378137), matching EPSG:3857/900913. That causes the ~0.33%
+	Y-scale distortion the MSDN documentation calls out in the docblock
+	of GlobalMercator.go, which is fine for map display but wrong if the numeric
+	coordinates themselves need to agree with EPSG:3395.
+
+	GlobalMercatorEllipsoidal keeps the same tile/pixel/meters pyramid
+	math but projects lat/lon using the true WGS84 ellipsoid, so it
+	drops into the same pipelines wherever EPSG:3395 rather than
+	EPSG:3857 is required.
+*/
+package Mercator
+
+import "math"
+
+const (
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+)
+
+type GlobalMercatorEllipsoidal struct {
+	tileSize          float64
+	initialResolution float64
+	originShift       float64
+	a                 float64
+	e                 float64
+}
+
+func NewGlobalMercatorEllipsoidal(tileSize float64) *GlobalMercatorEllipsoidal {
+	e := math.Sqrt(2*wgs84F - wgs84F*wgs84F)
+
+	return &GlobalMercatorEllipsoidal{
+		tileSize,
+		2 * math.Pi * wgs84A / tileSize,
+		2 * math.Pi * wgs84A / 2.0,
+		wgs84A,
+		e,
+	}
+}
+
+//Converts given lat/lon in WGS84 Datum to XY in Ellipsoidal Mercator EPSG:3395
+func (this *GlobalMercatorEllipsoidal) LatLonToMeters(lat float64, lon float64) (float64, float64) {
+	mx := lon * math.Pi / 180.0 * this.a
+
+	phi := lat * math.Pi / 180.0
+	esinPhi := this.e * math.Sin(phi)
+	my := this.a * math.Log(math.Tan(math.Pi/4+phi/2)*math.Pow((1-esinPhi)/(1+esinPhi), this.e/2))
+
+	return mx, my
+}
+
+//Converts XY point from Ellipsoidal Mercator EPSG:3395 to lat/lon in WGS84 Datum.
+//The inverse has no closed form, so the geodetic latitude is recovered from
+//the isometric latitude (my/a) by fixed-point iteration starting from the
+//conformal latitude; the loop stops once a step is below 1e-12 radians, or
+//after at most 6 iterations.
+func (this *GlobalMercatorEllipsoidal) MetersToLatLon(mx float64, my float64) (float64, float64) {
+	lon := (mx / this.a) * 180.0 / math.Pi
+
+	t := math.Exp(-my / this.a)
+	phi := math.Pi/2 - 2*math.Atan(t)
+	for i := 0; i < 6; i++ {
+		esinPhi := this.e * math.Sin(phi)
+		phiNext := math.Pi/2 - 2*math.Atan(t*math.Pow((1-esinPhi)/(1+esinPhi), this.e/2))
+		if math.Abs(phiNext-phi) < 1e-12 {
+			phi = phiNext
+			break
+		}
+		phi = phiNext
+	}
+
+	lat := phi * 180.0 / math.Pi
+	return lat, lon
+}
+
+//Converts pixel coordinates in given zoom level of pyramid to EPSG:3395
+func (this *GlobalMercatorEllipsoidal) PixelsToMeters(px float64, py float64, zoom int64) (float64, float64) {
+	res := this.Resolution(zoom)
+	mx := px*res - this.originShift
+	my := py*res - this.originShift
+	return mx, my
+}
+
+//Converts EPSG:3395 to pyramid pixel coordinates in given zoom level
+func (this *GlobalMercatorEllipsoidal) MetersToPixels(mx float64, my float64, zoom int64) (float64, float64) {
+	res := this.Resolution(zoom)
+	px := (mx + this.originShift) / res
+	py := (my + this.originShift) / res
+	return px, py
+}
+
+//Returns a tile covering region in given pixel coordinates
+func (this *GlobalMercatorEllipsoidal) PixelsToTile(px float64, py float64) (int64, int64) {
+	tx := int64(math.Ceil(px/this.tileSize) - 1)
+	ty := int64(math.Ceil(py/this.tileSize) - 1)
+	return tx, ty
+}
+
+//Move the origin of pixel coordinates to top-left corner
+func (this *GlobalMercatorEllipsoidal) PixelsToRaster(px float64, py float64, zoom int64) (float64, float64) {
+	mapSize := uint(this.tileSize) << uint(zoom)
+	return px, float64(mapSize) - py
+}
+
+//Returns tile for given mercator coordinates
+func (this *GlobalMercatorEllipsoidal) MetersToTile(mx float64, my float64, zoom int64) (int64, int64) {
+	px, py := this.MetersToPixels(mx, my, zoom)
+	return this.PixelsToTile(px, py)
+}
+
+//Returns bounds of the given tile in EPSG:3395 coordinates
+func (this *GlobalMercatorEllipsoidal) TileBounds(tx float64, ty float64, zoom int64) (float64, float64, float64, float64) {
+	minx, miny := this.PixelsToMeters(tx*this.tileSize, ty*this.tileSize, zoom)
+	maxx, maxy := this.PixelsToMeters((tx+1)*this.tileSize, (ty+1)*this.tileSize, zoom)
+	return minx, miny, maxx, maxy
+}
+
+//Returns bounds of the given tile in latitude/longitude using WGS84 datum
+func (this *GlobalMercatorEllipsoidal) TileLatLonBounds(tx float64, ty float64, zoom int64) (float64, float64, float64, float64) {
+	minx, miny, maxx, maxy := this.TileBounds(tx, ty, zoom)
+	minLat, minLon := this.MetersToLatLon(minx, miny)
+	maxLat, maxLon := this.MetersToLatLon(maxx, maxy)
+
+	return minLat, minLon, maxLat, maxLon
+}
+
+//Resolution (meters/pixel) for given zoom level (measured at Equator)
+func (this *GlobalMercatorEllipsoidal) Resolution(zoom int64) float64 {
+	return this.initialResolution / math.Pow(2, float64(zoom))
+}
@@ -0,0 +1,134 @@
+package Mercator
+
+import (
+	"math"
+	"testing"
+)
+
+func floatEquals(a, b float64, eps float64) bool {
+	return math.Abs(a-b) < eps
+}
+
+func TestGoogleTileRoundTrip(t *testing.T) {
+	m := NewGlobalMercator(256)
+
+	tx, ty, zoom := int64(3), int64(5), int64(3)
+	gx, gy := m.GoogleTile(tx, ty, zoom)
+
+	if gx != 3 {
+		t.Errorf("expected gx 3, got %d", gx)
+	}
+	if gy != 2 {
+		t.Errorf("expected gy 2, got %d", gy)
+	}
+
+	backTx, backTy := m.GoogleTileToTile(gx, gy, zoom)
+	if backTx != tx || backTy != ty {
+		t.Errorf("expected round trip (%d, %d), got (%d, %d)", tx, ty, backTx, backTy)
+	}
+}
+
+func TestGoogleTileBoundsMatchesTileBounds(t *testing.T) {
+	m := NewGlobalMercator(256)
+
+	tx, ty, zoom := int64(3), int64(5), int64(3)
+	gx, gy := m.GoogleTile(tx, ty, zoom)
+
+	minx, miny, maxx, maxy := m.TileBounds(float64(tx), float64(ty), zoom)
+	gminx, gminy, gmaxx, gmaxy := m.GoogleTileBounds(gx, gy, zoom)
+
+	if !floatEquals(minx, gminx, 1e-6) || !floatEquals(miny, gminy, 1e-6) ||
+		!floatEquals(maxx, gmaxx, 1e-6) || !floatEquals(maxy, gmaxy, 1e-6) {
+		t.Errorf("expected GoogleTileBounds to match TileBounds, got (%g,%g,%g,%g) vs (%g,%g,%g,%g)",
+			minx, miny, maxx, maxy, gminx, gminy, gmaxx, gmaxy)
+	}
+}
+
+func TestQuadTreeRoundTrip(t *testing.T) {
+	m := NewGlobalMercator(256)
+
+	tx, ty, zoom := int64(3), int64(5), int64(3)
+	quadkey := m.QuadTree(tx, ty, zoom)
+
+	if quadkey != "031" {
+		t.Errorf("expected quadkey \"031\", got %q", quadkey)
+	}
+
+	backTx, backTy, backZoom := m.QuadTreeToTile(quadkey)
+	if backTx != tx || backTy != ty || backZoom != zoom {
+		t.Errorf("expected round trip (%d, %d, %d), got (%d, %d, %d)", tx, ty, zoom, backTx, backTy, backZoom)
+	}
+}
+
+func TestGlobalMercatorEllipsoidalLatLonToMeters(t *testing.T) {
+	m := NewGlobalMercatorEllipsoidal(256)
+
+	mx, my := m.LatLonToMeters(51.5287718, -0.2416819)
+
+	if !floatEquals(mx, -26903.906, 0.001) {
+		t.Errorf("expected mx -26903.906, got %g", mx)
+	}
+	if !floatEquals(my, 6681891.02, 0.01) {
+		t.Errorf("expected my 6681891.02, got %g", my)
+	}
+}
+
+func TestGlobalMercatorEllipsoidalRoundTrip(t *testing.T) {
+	m := NewGlobalMercatorEllipsoidal(256)
+
+	lat, lon := 51.5287718, -0.2416819
+	mx, my := m.LatLonToMeters(lat, lon)
+	backLat, backLon := m.MetersToLatLon(mx, my)
+
+	if !floatEquals(backLat, lat, 1e-6) {
+		t.Errorf("expected lat %g, got %g", lat, backLat)
+	}
+	if !floatEquals(backLon, lon, 1e-6) {
+		t.Errorf("expected lon %g, got %g", lon, backLon)
+	}
+}
+
+func TestZoomForPixelSize(t *testing.T) {
+	m := NewGlobalMercator(256)
+
+	if zoom := m.ZoomForPixelSize(m.Resolution(5) * 1.5); zoom != 4 {
+		t.Errorf("expected zoom 4, got %d", zoom)
+	}
+	if zoom := m.ZoomForPixelSize(m.Resolution(0) * 2); zoom != 0 {
+		t.Errorf("expected zoom 0, got %d", zoom)
+	}
+}
+
+func TestScaleForZoomAndZoomForScale(t *testing.T) {
+	m := NewGlobalMercator(256)
+
+	scale := m.ScaleForZoom(5, 96)
+	if !floatEquals(scale, 18489334.71591035, 0.001) {
+		t.Errorf("expected scale 18489334.71591035, got %g", scale)
+	}
+
+	if zoom := m.ZoomForScale(scale, 96); zoom != int(m.ZoomForPixelSize(m.Resolution(5))) {
+		t.Errorf("expected ZoomForScale to agree with ZoomForPixelSize at the same resolution, got %d", zoom)
+	}
+}
+
+func TestLODs(t *testing.T) {
+	m := NewGlobalMercator(256)
+
+	lods := m.LODs(0, 3, 96)
+	if len(lods) != 4 {
+		t.Fatalf("expected 4 LODs, got %d", len(lods))
+	}
+
+	for i, lod := range lods {
+		if lod.Level != int64(i) {
+			t.Errorf("expected level %d, got %d", i, lod.Level)
+		}
+		if !floatEquals(lod.Resolution, m.Resolution(int64(i)), 1e-9) {
+			t.Errorf("expected resolution %g at level %d, got %g", m.Resolution(int64(i)), i, lod.Resolution)
+		}
+		if !floatEquals(lod.Scale, m.ScaleForZoom(int64(i), 96), 1e-6) {
+			t.Errorf("expected scale %g at level %d, got %g", m.ScaleForZoom(int64(i), 96), i, lod.Scale)
+		}
+	}
+}
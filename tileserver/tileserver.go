@@ -0,0 +1,289 @@
+/*
+	WMTS/TMS Tile Server
+	--------------------
+
+	Turns the projection math in Mercator.GlobalMercator and
+	Geodetic.GlobalGeodetic into a servable tile pyramid.
+
+	Three request styles are understood on the same http.Handler:
+
+	  TMS        /tms/1.0.0/{layer}/{z}/{x}/{y}.png   (origin bottom-left)
+	  Google XYZ /xyz/{layer}/{z}/{x}/{y}.png         (origin top-left)
+	  WMTS KVP    ?SERVICE=WMTS&REQUEST=GetTile&TILEMATRIXSET=...&TILEMATRIX={z}&TILECOL={x}&TILEROW={y}
+
+	Rendering is delegated to a TileSource so the server carries no
+	opinion about where pixels come from.
+*/
+package tileserver
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/varunpant/CoordinateConversions/Geodetic"
+	"github.com/varunpant/CoordinateConversions/Mercator"
+)
+
+//TileSource renders a tile covering the given bounds (minx, miny, maxx, maxy)
+//in the given SRS ("EPSG:3857" or "EPSG:4326") at size x size pixels.
+type TileSource interface {
+	Render(bounds [4]float64, srs string, size int) (image.Image, error)
+}
+
+//TileMatrixSet names the two WMTS TileMatrixSets this server understands.
+const (
+	TileMatrixSet3857 = "EPSG:3857"
+	TileMatrixSet4326 = "EPSG:4326"
+)
+
+//Handler serves TMS, Google XYZ and WMTS KVP tile requests for a set of
+//named layers backed by TileSources.
+type Handler struct {
+	TileSize int64
+	Layers   map[string]TileSource
+
+	mercator *Mercator.GlobalMercator
+	geodetic *Geodetic.GlobalGeodetic
+}
+
+//NewHandler builds a Handler serving the given layers at tileSize pixels
+//per tile (256 is the conventional value).
+func NewHandler(tileSize int64, layers map[string]TileSource) *Handler {
+	return &Handler{
+		TileSize: tileSize,
+		Layers:   layers,
+		mercator: Mercator.NewGlobalMercator(float64(tileSize)),
+		geodetic: Geodetic.NewGlobalGeodetic(tileSize),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("SERVICE") == "WMTS" {
+		h.serveWMTS(w, r)
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/tms/1.0.0/"):
+		h.serveTMS(w, r, strings.TrimPrefix(r.URL.Path, "/tms/1.0.0/"))
+	case strings.HasPrefix(r.URL.Path, "/xyz/"):
+		h.serveXYZ(w, r, strings.TrimPrefix(r.URL.Path, "/xyz/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) serveWMTS(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch strings.ToUpper(q.Get("REQUEST")) {
+	case "GETCAPABILITIES":
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(xml.Header))
+		doc, err := h.GetCapabilities()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(doc)
+	case "GETTILE":
+		layer := q.Get("LAYER")
+		tileMatrixSet := q.Get("TILEMATRIXSET")
+		z, zerr := strconv.ParseInt(q.Get("TILEMATRIX"), 10, 64)
+		x, xerr := strconv.ParseInt(q.Get("TILECOL"), 10, 64)
+		y, yerr := strconv.ParseInt(q.Get("TILEROW"), 10, 64)
+		if zerr != nil || xerr != nil || yerr != nil {
+			http.Error(w, "invalid TILEMATRIX/TILECOL/TILEROW", http.StatusBadRequest)
+			return
+		}
+
+		//WMTS TILECOL/TILEROW use the TopLeftCorner origin (same numbering as
+		//Google XYZ), not the TMS bottom-left origin renderTile expects.
+		var tx, ty int64
+		switch tileMatrixSet {
+		case TileMatrixSet3857:
+			tx, ty = h.mercator.GoogleTileToTile(x, y, z)
+		case TileMatrixSet4326:
+			tx, ty = x, topLeftRowToTMSRow(y, z)
+		default:
+			http.Error(w, fmt.Sprintf("unknown TILEMATRIXSET %q", tileMatrixSet), http.StatusBadRequest)
+			return
+		}
+		h.renderTile(w, layer, tileMatrixSet, tx, ty, z)
+	default:
+		http.Error(w, "unsupported WMTS REQUEST", http.StatusBadRequest)
+	}
+}
+
+func (h *Handler) serveTMS(w http.ResponseWriter, r *http.Request, rest string) {
+	layer, x, y, z, ok := parseTileRequest(rest)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	h.renderTile(w, layer, TileMatrixSet3857, x, y, z)
+}
+
+func (h *Handler) serveXYZ(w http.ResponseWriter, r *http.Request, rest string) {
+	layer, gx, gy, z, ok := parseTileRequest(rest)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	tx, ty := h.mercator.GoogleTileToTile(gx, gy, z)
+	h.renderTile(w, layer, TileMatrixSet3857, tx, ty, z)
+}
+
+//renderTile resolves bounds for (x, y, zoom) in the given TileMatrixSet,
+//asks the named layer's TileSource to render them, and writes a PNG.
+func (h *Handler) renderTile(w http.ResponseWriter, layer string, tileMatrixSet string, x int64, y int64, zoom int64) {
+	source, ok := h.Layers[layer]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown layer %q", layer), http.StatusNotFound)
+		return
+	}
+
+	var bounds [4]float64
+	switch tileMatrixSet {
+	case TileMatrixSet3857:
+		minx, miny, maxx, maxy := h.mercator.TileBounds(float64(x), float64(y), zoom)
+		bounds = [4]float64{minx, miny, maxx, maxy}
+	case TileMatrixSet4326:
+		minx, miny, maxx, maxy := h.geodetic.TileBounds(float64(x), float64(y), zoom)
+		bounds = [4]float64{minx, miny, maxx, maxy}
+	default:
+		http.Error(w, fmt.Sprintf("unknown TILEMATRIXSET %q", tileMatrixSet), http.StatusBadRequest)
+		return
+	}
+
+	img, err := source.Render(bounds, tileMatrixSet, int(h.TileSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	if err := png.Encode(w, img); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//topLeftRowToTMSRow flips a TileMatrixRow/TILEROW given in TopLeftCorner
+//notation (row 0 at the north edge) to TMS notation (row 0 at the south edge).
+func topLeftRowToTMSRow(row int64, zoom int64) int64 {
+	return (int64(1)<<uint(zoom) - 1) - row
+}
+
+//parseTileRequest splits a "{layer}/{z}/{x}/{y}.png" path tail into its parts.
+func parseTileRequest(rest string) (layer string, x int64, y int64, z int64, ok bool) {
+	rest = strings.TrimSuffix(rest, ".png")
+	parts := strings.Split(rest, "/")
+	if len(parts) != 4 {
+		return "", 0, 0, 0, false
+	}
+	layer = parts[0]
+	zz, zerr := strconv.ParseInt(parts[1], 10, 64)
+	xx, xerr := strconv.ParseInt(parts[2], 10, 64)
+	yy, yerr := strconv.ParseInt(parts[3], 10, 64)
+	if zerr != nil || xerr != nil || yerr != nil {
+		return "", 0, 0, 0, false
+	}
+	return layer, xx, yy, zz, true
+}
+
+//capabilities is the minimal WMTS GetCapabilities document structure needed
+//to advertise this server's layers and TileMatrixSets. Fields in the OGC OWS
+//Common namespace are tagged "namespace local" rather than a literal "ows:"
+//prefix: encoding/xml treats a colon in a tag as a literal string, not a
+//resolvable prefix, so a document written with "ows:Identifier" tags could
+//not be read back with the same tags.
+type capabilities struct {
+	XMLName xml.Name `xml:"Capabilities"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Contents struct {
+		Layers         []capLayer         `xml:"Layer"`
+		TileMatrixSets []capTileMatrixSet `xml:"TileMatrixSet"`
+	} `xml:"Contents"`
+}
+
+type capLayer struct {
+	Title              string   `xml:"http://www.opengis.net/ows/1.1 Title"`
+	Identifier         string   `xml:"http://www.opengis.net/ows/1.1 Identifier"`
+	TileMatrixSetLinks []string `xml:"TileMatrixSetLink>TileMatrixSet"`
+}
+
+type capTileMatrixSet struct {
+	Identifier   string          `xml:"http://www.opengis.net/ows/1.1 Identifier"`
+	SupportedCRS string          `xml:"http://www.opengis.net/ows/1.1 SupportedCRS"`
+	TileMatrix   []capTileMatrix `xml:"TileMatrix"`
+}
+
+type capTileMatrix struct {
+	Identifier       string  `xml:"http://www.opengis.net/ows/1.1 Identifier"`
+	ScaleDenominator float64 `xml:"ScaleDenominator"`
+	TileWidth        int64   `xml:"TileWidth"`
+	TileHeight       int64   `xml:"TileHeight"`
+}
+
+//GetCapabilities builds the WMTS GetCapabilities document for this server's
+//layers, advertising TileMatrixSets derived from Resolution(zoom) for both
+//EPSG:3857 and EPSG:4326.
+func (h *Handler) GetCapabilities() ([]byte, error) {
+	if len(h.Layers) == 0 {
+		return nil, errors.New("tileserver: no layers configured")
+	}
+
+	caps := capabilities{
+		Xmlns:   "http://www.opengis.net/wmts/1.0",
+		Version: "1.0.0",
+	}
+
+	for name := range h.Layers {
+		caps.Contents.Layers = append(caps.Contents.Layers, capLayer{
+			Title:              name,
+			Identifier:         name,
+			TileMatrixSetLinks: []string{TileMatrixSet3857, TileMatrixSet4326},
+		})
+	}
+
+	caps.Contents.TileMatrixSets = []capTileMatrixSet{
+		h.tileMatrixSet(TileMatrixSet3857, "urn:ogc:def:crs:EPSG::3857", h.mercator.Resolution),
+		h.tileMatrixSet(TileMatrixSet4326, "urn:ogc:def:crs:EPSG::4326", func(zoom int64) float64 {
+			return h.geodetic.Resolution(zoom) * metersPerDegree
+		}),
+	}
+
+	return xml.MarshalIndent(caps, "", "  ")
+}
+
+//metersPerDegree converts GlobalGeodetic's degrees/pixel resolution to
+//meters/pixel at the Equator, so it can share the WMTS scale denominator
+//formula below with GlobalMercator's meters/pixel resolution.
+const metersPerDegree = 111319.4908
+
+//tileMatrixSet builds the TileMatrix list (zoom 0..21) for one TileMatrixSet,
+//converting each resolution (meters/pixel) to a scale denominator at the
+//standard 0.28mm pixel size used by WMTS.
+func (h *Handler) tileMatrixSet(identifier string, crs string, resolution func(int64) float64) capTileMatrixSet {
+	const pixelSizeMeters = 0.00028
+
+	set := capTileMatrixSet{
+		Identifier:   identifier,
+		SupportedCRS: crs,
+	}
+	for zoom := int64(0); zoom <= 21; zoom++ {
+		set.TileMatrix = append(set.TileMatrix, capTileMatrix{
+			Identifier:       strconv.FormatInt(zoom, 10),
+			ScaleDenominator: resolution(zoom) / pixelSizeMeters,
+			TileWidth:        h.TileSize,
+			TileHeight:       h.TileSize,
+		})
+	}
+	return set
+}
@@ -0,0 +1,140 @@
+package tileserver
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type recordingSource struct {
+	bounds [4]float64
+}
+
+func (s *recordingSource) Render(bounds [4]float64, srs string, size int) (image.Image, error) {
+	s.bounds = bounds
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	img.Set(0, 0, color.White)
+	return img, nil
+}
+
+func floatEquals(a, b float64, eps float64) bool {
+	return math.Abs(a-b) < eps
+}
+
+//A TMS request and the equivalent Google XYZ request for the same tile must
+//resolve to identical bounds once the XYZ path flips Y back to TMS notation.
+func TestTMSAndXYZAgreeOnBounds(t *testing.T) {
+	tms := &recordingSource{}
+	xyz := &recordingSource{}
+	handler := NewHandler(256, map[string]TileSource{
+		"tms": tms,
+		"xyz": xyz,
+	})
+
+	zoom := int64(4)
+	tx, ty := int64(3), int64(5)
+	gx, gy := handler.mercator.GoogleTile(tx, ty, zoom)
+
+	req := httptest.NewRequest("GET", "/tms/1.0.0/tms/4/3/5.png", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/xyz/xyz/4/%d/%d.png", gx, gy), nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	for i := range tms.bounds {
+		if !floatEquals(tms.bounds[i], xyz.bounds[i], 1e-6) {
+			t.Errorf("bounds[%d]: TMS %g != XYZ %g", i, tms.bounds[i], xyz.bounds[i])
+		}
+	}
+}
+
+//WMTS TILECOL/TILEROW use the TopLeftCorner origin, so a GetTile request must
+//resolve to the same bounds as the equivalent TMS request once the row is
+//flipped back. Covers both TileMatrixSets the handler understands.
+func TestWMTSGetTileAgreesWithTMS(t *testing.T) {
+	zoom := int64(4)
+	tx, ty := int64(3), int64(5)
+
+	t.Run("EPSG:3857", func(t *testing.T) {
+		tms := &recordingSource{}
+		wmts := &recordingSource{}
+		handler := NewHandler(256, map[string]TileSource{
+			"tms":  tms,
+			"wmts": wmts,
+		})
+
+		gx, gy := handler.mercator.GoogleTile(tx, ty, zoom)
+
+		req := httptest.NewRequest("GET", "/tms/1.0.0/tms/4/3/5.png", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		req = httptest.NewRequest("GET", fmt.Sprintf(
+			"/?SERVICE=WMTS&REQUEST=GetTile&LAYER=wmts&TILEMATRIXSET=EPSG:3857&TILEMATRIX=%d&TILECOL=%d&TILEROW=%d",
+			zoom, gx, gy), nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		for i := range tms.bounds {
+			if !floatEquals(tms.bounds[i], wmts.bounds[i], 1e-6) {
+				t.Errorf("bounds[%d]: TMS %g != WMTS %g", i, tms.bounds[i], wmts.bounds[i])
+			}
+		}
+	})
+
+	t.Run("EPSG:4326", func(t *testing.T) {
+		wmts := &recordingSource{}
+		handler := NewHandler(256, map[string]TileSource{
+			"wmts": wmts,
+		})
+
+		gy := topLeftRowToTMSRow(ty, zoom)
+
+		minx, miny, maxx, maxy := handler.geodetic.TileBounds(float64(tx), float64(ty), zoom)
+		expected := [4]float64{minx, miny, maxx, maxy}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf(
+			"/?SERVICE=WMTS&REQUEST=GetTile&LAYER=wmts&TILEMATRIXSET=EPSG:4326&TILEMATRIX=%d&TILECOL=%d&TILEROW=%d",
+			zoom, tx, gy), nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		for i := range expected {
+			if !floatEquals(expected[i], wmts.bounds[i], 1e-6) {
+				t.Errorf("bounds[%d]: expected %g, got %g", i, expected[i], wmts.bounds[i])
+			}
+		}
+	})
+}
+
+func TestGetCapabilitiesParsesBack(t *testing.T) {
+	handler := NewHandler(256, map[string]TileSource{
+		"basemap": &recordingSource{},
+	})
+
+	req := httptest.NewRequest("GET", "/?SERVICE=WMTS&REQUEST=GetCapabilities", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var parsed capabilities
+	if err := xml.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		t.Fatalf("could not parse GetCapabilities response: %v", err)
+	}
+
+	if len(parsed.Contents.Layers) != 1 {
+		t.Fatalf("expected 1 layer, got %d", len(parsed.Contents.Layers))
+	}
+	if parsed.Contents.Layers[0].Identifier != "basemap" {
+		t.Errorf("expected layer identifier %q, got %q", "basemap", parsed.Contents.Layers[0].Identifier)
+	}
+	if len(parsed.Contents.TileMatrixSets) != 2 {
+		t.Fatalf("expected 2 TileMatrixSets, got %d", len(parsed.Contents.TileMatrixSets))
+	}
+}